@@ -0,0 +1,136 @@
+package ygadata
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfig 从 TOML 或 YAML 文件(根据扩展名 .toml/.yaml/.yml 判断)中解析出
+// BatchConfig, 随后用环境变量覆盖同名字段, 使容器化部署可以在不重新编译的
+// 情况下调整配置. 支持的环境变量见 applyEnvOverrides. 解析完成后会对
+// ServerUrl/Project/Sk/BatchSize/Interval 做校验, 所有校验失败会通过
+// errors.Join 聚合后一并返回.
+func LoadConfig(path string) (BatchConfig, error) {
+	var config BatchConfig
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		if err := toml.Unmarshal(data, &config); err != nil {
+			return config, err
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return config, err
+		}
+	default:
+		return config, fmt.Errorf("yga: unsupported config file extension %q", filepath.Ext(path))
+	}
+
+	applyEnvOverrides(&config)
+
+	if err := validateConfig(config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// MustLoadConfig 与 LoadConfig 行为一致, 但在解析或校验失败时直接 panic,
+// 便于在 main() 中一行完成初始化.
+func MustLoadConfig(path string) BatchConfig {
+	config, err := LoadConfig(path)
+	if err != nil {
+		panic(err)
+	}
+	return config
+}
+
+// applyEnvOverrides 用环境变量覆盖 config 中的同名字段. 未设置的变量不做处理;
+// 已设置的字符串变量即使为空也会覆盖原值; 数值/布尔变量解析失败时保留原值不变.
+func applyEnvOverrides(config *BatchConfig) {
+	if v, ok := os.LookupEnv("YGA_SERVER_URL"); ok {
+		config.ServerUrl = v
+	}
+	if v, ok := os.LookupEnv("YGA_PROJECT"); ok {
+		config.Project = v
+	}
+	if v, ok := os.LookupEnv("YGA_USER"); ok {
+		config.User = v
+	}
+	if v, ok := os.LookupEnv("YGA_SK"); ok {
+		config.Sk = v
+	}
+	if v, ok := os.LookupEnv("YGA_BATCH_SIZE"); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			config.BatchSize = n
+		}
+	}
+	if v, ok := os.LookupEnv("YGA_TIMEOUT"); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			config.Timeout = n
+		}
+	}
+	if v, ok := os.LookupEnv("YGA_COMPRESS"); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			config.Compress = b
+		}
+	}
+	if v, ok := os.LookupEnv("YGA_AUTO_FLUSH"); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			config.AutoFlush = b
+		}
+	}
+	if v, ok := os.LookupEnv("YGA_INTERVAL"); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			config.Interval = n
+		}
+	}
+	if v, ok := os.LookupEnv("YGA_CACHE_CAPACITY"); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			config.CacheCapacity = n
+		}
+	}
+	if v, ok := os.LookupEnv("YGA_WORKERS"); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			config.Workers = n
+		}
+	}
+	if v, ok := os.LookupEnv("YGA_BLOCK_ON_FULL"); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			config.BlockOnFull = b
+		}
+	}
+}
+
+// validateConfig 校验 config 中与容器化部署最相关的字段, 把所有校验失败聚合
+// 后一并返回, 而不是在第一个错误处中断, 方便调用方一次性看到所有问题.
+func validateConfig(config BatchConfig) error {
+	var errs []error
+	if config.ServerUrl == "" {
+		errs = append(errs, errors.New("yga: ServerUrl must not be empty"))
+	}
+	if config.Project == "" {
+		errs = append(errs, errors.New("yga: Project must not be empty"))
+	}
+	if config.Sk == "" {
+		errs = append(errs, errors.New("yga: Sk must not be empty"))
+	}
+	if config.BatchSize < 0 || config.BatchSize > MaxBatchSize {
+		errs = append(errs, fmt.Errorf("yga: BatchSize must be between 0 and %d", MaxBatchSize))
+	}
+	if config.Interval < 0 {
+		errs = append(errs, errors.New("yga: Interval must not be negative"))
+	}
+	return errors.Join(errs...)
+}
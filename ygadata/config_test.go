@@ -0,0 +1,163 @@
+package ygadata
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigParsesTomlAndYaml(t *testing.T) {
+	cases := []struct {
+		name     string
+		filename string
+		content  string
+	}{
+		{"toml", "config.toml", `
+server_url = "https://example.com"
+project = "p"
+sk = "sk"
+batch_size = 10
+`},
+		{"yaml", "config.yaml", `
+server_url: https://example.com
+project: p
+sk: sk
+batch_size: 10
+`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), c.filename)
+			if err := os.WriteFile(path, []byte(c.content), 0o644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			config, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if config.ServerUrl != "https://example.com" || config.Project != "p" || config.Sk != "sk" || config.BatchSize != 10 {
+				t.Errorf("LoadConfig() = %+v, want ServerUrl/Project/Sk/BatchSize from file", config)
+			}
+		})
+	}
+}
+
+func TestLoadConfigRejectsUnsupportedExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig() with .json, want unsupported extension error")
+	}
+}
+
+func TestLoadConfigAggregatesValidationErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(`batch_size = 9999`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("LoadConfig() error = nil, want aggregated validation error")
+	}
+	for _, want := range []string{"ServerUrl", "Project", "Sk", "BatchSize"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadConfig() error = %q, want it to mention %q", err.Error(), want)
+		}
+	}
+}
+
+func TestMustLoadConfigPanicsOnInvalidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(``), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustLoadConfig() did not panic on invalid config")
+		}
+	}()
+	MustLoadConfig(path)
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	env := map[string]string{
+		"YGA_SERVER_URL":     "https://override.example.com",
+		"YGA_PROJECT":        "override-project",
+		"YGA_USER":           "override-user",
+		"YGA_SK":             "override-sk",
+		"YGA_BATCH_SIZE":     "50",
+		"YGA_TIMEOUT":        "1000",
+		"YGA_COMPRESS":       "true",
+		"YGA_AUTO_FLUSH":     "true",
+		"YGA_INTERVAL":       "60",
+		"YGA_CACHE_CAPACITY": "100",
+		"YGA_WORKERS":        "4",
+		"YGA_BLOCK_ON_FULL":  "true",
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+
+	config := BatchConfig{ServerUrl: "https://original.example.com"}
+	applyEnvOverrides(&config)
+
+	want := BatchConfig{
+		ServerUrl:     "https://override.example.com",
+		Project:       "override-project",
+		User:          "override-user",
+		Sk:            "override-sk",
+		BatchSize:     50,
+		Timeout:       1000,
+		Compress:      true,
+		AutoFlush:     true,
+		Interval:      60,
+		CacheCapacity: 100,
+		Workers:       4,
+		BlockOnFull:   true,
+	}
+	if config != want {
+		t.Errorf("applyEnvOverrides() = %+v, want %+v", config, want)
+	}
+}
+
+func TestApplyEnvOverridesIgnoresUnparsableValues(t *testing.T) {
+	t.Setenv("YGA_BATCH_SIZE", "not-a-number")
+	t.Setenv("YGA_COMPRESS", "not-a-bool")
+
+	config := BatchConfig{BatchSize: 5, Compress: true}
+	applyEnvOverrides(&config)
+
+	if config.BatchSize != 5 {
+		t.Errorf("BatchSize = %d, want unchanged 5 when env value is unparsable", config.BatchSize)
+	}
+	if config.Compress != true {
+		t.Errorf("Compress = %v, want unchanged true when env value is unparsable", config.Compress)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid := BatchConfig{ServerUrl: "https://example.com", Project: "p", Sk: "sk", BatchSize: 10}
+	if err := validateConfig(valid); err != nil {
+		t.Errorf("validateConfig(%+v) error = %v, want nil", valid, err)
+	}
+
+	invalid := BatchConfig{BatchSize: -1, Interval: -1}
+	err := validateConfig(invalid)
+	if err == nil {
+		t.Fatal("validateConfig() error = nil, want aggregated errors")
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("validateConfig() error = %v, want a joined error", err)
+	}
+	if len(joined.Unwrap()) != 5 {
+		t.Errorf("got %d joined errors, want 5 (ServerUrl, Project, Sk, BatchSize, Interval)", len(joined.Unwrap()))
+	}
+	if !errors.Is(err, err) {
+		t.Error("errors.Is() sanity check failed")
+	}
+}
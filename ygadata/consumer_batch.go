@@ -3,17 +3,27 @@ package ygadata
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"runtime"
 	"sync"
 	"time"
 )
 
+// ErrQueueFull 在队列已满且 BlockOnFull 为 false 时返回, 供调用方感知背压
+// 而不是被无限制地阻塞或让 goroutine 数量无限增长.
+var ErrQueueFull = errors.New("yga: upload queue is full")
+
+// ErrConsumerClosed 在 Close 之后仍有 Add/Flush 调用到达时返回, 取代直接向
+// 已关闭的 jobs 发送数据导致 panic.
+var ErrConsumerClosed = errors.New("yga: batch consumer is closed")
+
 type Auth struct {
 	Project string `json:"project"`
 	User    string `json:"user"`
@@ -38,19 +48,40 @@ type BatchConsumer struct {
 	batchSize     int
 	cacheBuffer   []UploadData // 缓存
 	cacheCapacity int          // 缓存最大容量
+	spool         *diskSpool   // 落盘队列, 为空表示未启用
+	retry         RetryPolicy  // 重试策略
+	deadLetter    Consumer     // 重试耗尽后的兜底 Consumer, 为空表示不做兜底
+	ctx           context.Context
+	cancel        context.CancelFunc
+	jobs          chan []UploadData // 待上传的批次队列, 由固定数量的 worker 消费
+	blockOnFull   bool              // 队列已满时 Add 是否阻塞等待, 默认 false 时立即返回 ErrQueueFull
+	workerWg      sync.WaitGroup
+	bgStop        chan struct{}    // 通知 AutoFlush/落盘重放等后台协程停止, 由 Close 关闭
+	bgWg          sync.WaitGroup   // 等待后台协程在 Close 中确实退出
+	shutdownMu    sync.RWMutex     // 保护 closed, 确保 enqueue 写入 jobs 和 Close 关闭 jobs 互斥, 避免并发 Add/Flush 在 Close 之后 panic
+	closed        bool             // Close 调用后置为 true, enqueue 发现后直接返回 ErrConsumerClosed, 不再写入 jobs
+	metrics       MetricsCollector // 指标采集器, 默认为 noopMetrics
+	logger        Logger           // 结构化日志, 默认为 stdLogger
 }
 
 type BatchConfig struct {
-	ServerUrl     string // 接收端地址
-	Project       string // 项目ID
-	User          string // 用户名
-	Sk            string // 秘钥
-	BatchSize     int    // 批量上传数目
-	Timeout       int    // 网络请求超时时间, 单位毫秒
-	Compress      bool   // 是否数据压缩
-	AutoFlush     bool   // 自动上传
-	Interval      int    // 自动上传间隔，单位秒
-	CacheCapacity int    // 缓存最大容量
+	ServerUrl     string           `toml:"server_url" yaml:"server_url"`         // 接收端地址
+	Project       string           `toml:"project" yaml:"project"`               // 项目ID
+	User          string           `toml:"user" yaml:"user"`                     // 用户名
+	Sk            string           `toml:"sk" yaml:"sk"`                         // 秘钥
+	BatchSize     int              `toml:"batch_size" yaml:"batch_size"`         // 批量上传数目
+	Timeout       int              `toml:"timeout" yaml:"timeout"`               // 网络请求超时时间, 单位毫秒
+	Compress      bool             `toml:"compress" yaml:"compress"`             // 是否数据压缩
+	AutoFlush     bool             `toml:"auto_flush" yaml:"auto_flush"`         // 自动上传
+	Interval      int              `toml:"interval" yaml:"interval"`             // 自动上传间隔，单位秒
+	CacheCapacity int              `toml:"cache_capacity" yaml:"cache_capacity"` // 缓存最大容量
+	DiskSpool     *DiskSpoolConfig `toml:"disk_spool" yaml:"disk_spool"`         // 落盘队列配置, 为空则沿用纯内存缓存, 不影响零配置使用
+	Retry         RetryPolicy      `toml:"-" yaml:"-"`                           // 重试策略, 零值时使用默认的指数退避 + 全抖动, 不支持从配置文件加载
+	DeadLetter    Consumer         `toml:"-" yaml:"-"`                           // 重试耗尽后的兜底 Consumer, 例如落盘或记录到其它系统, 不支持从配置文件加载
+	Workers       int              `toml:"workers" yaml:"workers"`               // 上传 worker 数量, 默认 runtime.NumCPU()
+	BlockOnFull   bool             `toml:"block_on_full" yaml:"block_on_full"`   // 队列已满时 Add 是否阻塞等待, 默认 false 时立即返回 ErrQueueFull
+	Metrics       MetricsCollector `toml:"-" yaml:"-"`                           // 指标采集器, 为空时不采集任何指标, 不支持从配置文件加载
+	Logger        Logger           `toml:"-" yaml:"-"`                           // 结构化日志实现, 为空时使用标准库 log 包, 不支持从配置文件加载
 }
 
 const (
@@ -138,6 +169,21 @@ func initBatchConsumer(config BatchConfig) (Consumer, error) {
 	} else {
 		timeout = config.Timeout
 	}
+	workers := config.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	metrics := config.Metrics
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = stdLogger{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 	c := &BatchConsumer{
 		serverUrl:     u.String(),
 		project:       config.Project,
@@ -151,6 +197,31 @@ func initBatchConsumer(config BatchConfig) (Consumer, error) {
 		buffer:        make([]UploadData, 0, batchSize),
 		cacheCapacity: cacheCapacity,
 		cacheBuffer:   make([]UploadData, 0, cacheCapacity),
+		retry:         config.Retry.withDefaults(),
+		deadLetter:    config.DeadLetter,
+		ctx:           ctx,
+		cancel:        cancel,
+		jobs:          make(chan []UploadData, workers),
+		blockOnFull:   config.BlockOnFull,
+		bgStop:        make(chan struct{}),
+		metrics:       metrics,
+		logger:        logger,
+	}
+
+	for i := 0; i < workers; i++ {
+		c.workerWg.Add(1)
+		go c.worker()
+	}
+
+	if config.DiskSpool != nil {
+		spool, err := newDiskSpool(*config.DiskSpool)
+		if err != nil {
+			return nil, err
+		}
+		c.spool = spool
+		// 重放崩溃前遗留的历史段, 确保它们不会被悄悄丢弃
+		c.bgWg.Add(1)
+		go c.replaySpool()
 	}
 
 	var interval int
@@ -160,12 +231,18 @@ func initBatchConsumer(config BatchConfig) (Consumer, error) {
 		interval = config.Interval
 	}
 	if config.AutoFlush {
+		c.bgWg.Add(1)
 		go func() {
+			defer c.bgWg.Done()
 			ticker := time.NewTicker(time.Duration(interval) * time.Second)
 			defer ticker.Stop()
 			for {
-				<-ticker.C
-				_ = c.Flush()
+				select {
+				case <-c.bgStop:
+					return
+				case <-ticker.C:
+					_ = c.Flush()
+				}
 			}
 		}()
 	}
@@ -176,7 +253,9 @@ func (c *BatchConsumer) Add(d Data) error {
 	c.bufferMutex.Lock()
 	dstr, _ := json.Marshal(d)
 	c.buffer = append(c.buffer, UploadData{Auth{Project: c.project, User: c.user, Sign: c.MakeSign(d)}, string(dstr)})
+	c.metrics.SetBufferSize(len(c.buffer))
 	c.bufferMutex.Unlock()
+	c.metrics.IncEventsEnqueued()
 
 	if c.getBufferLength() >= c.batchSize || c.getCacheLength() > 0 {
 		err := c.Flush()
@@ -201,6 +280,8 @@ func (c *BatchConsumer) Flush() error {
 		if len(c.cacheBuffer) > c.cacheCapacity {
 			c.cacheBuffer = c.cacheBuffer[1:]
 		}
+		c.metrics.SetCacheSize(len(c.cacheBuffer))
+		c.metrics.SetBufferSize(len(c.buffer))
 	}()
 
 	if len(c.cacheBuffer) == 0 || len(c.buffer) >= c.batchSize {
@@ -209,7 +290,17 @@ func (c *BatchConsumer) Flush() error {
 		}
 		c.buffer = make([]UploadData, 0, c.batchSize)
 	}
+	n := len(c.cacheBuffer)
 	err := c.uploadEvents()
+	if err != nil {
+		reason := "queue_full"
+		if errors.Is(err, ErrConsumerClosed) {
+			reason = "consumer_closed"
+		}
+		for i := 0; i < n; i++ {
+			c.metrics.IncEventsDropped(reason)
+		}
+	}
 	return err
 }
 
@@ -219,28 +310,188 @@ func (c *BatchConsumer) uploadEvents() error {
 	copy(buffers[:], c.cacheBuffer)
 	//清除缓存的数据
 	c.cacheBuffer = make([]UploadData, 0, c.cacheCapacity)
-	for _, buffer := range buffers {
-		jdata, err := json.Marshal(buffer)
-		if err == nil {
-			params := parseTime(jdata)
-			go func() {
-				for i := 0; i < 3; i++ {
-					statusCode, code, msg, err := c.send(params)
-					if statusCode == 200 && code == 10000 && err == nil {
-						break
-					} else {
-						log.Println(params, "==>", msg)
-						if i == 2 {
-							log.Println(params, "-全部重试都失败,请检查")
-						}
-					}
-				}
-			}()
+	if len(buffers) == 0 {
+		return nil
+	}
+	if err := c.enqueue(buffers); err != nil {
+		// 队列已满时这批数据不能凭空消失, 和重试耗尽时一样落盘或转交
+		// DeadLetter 兜底, 而不是跟随 cacheBuffer 一起被悄悄清空.
+		for _, b := range buffers {
+			c.deadLetterOrSpool(b)
 		}
+		return err
 	}
 	return nil
 }
 
+// enqueue 把一个待上传的批次交给 worker 池. BlockOnFull 为 true 时阻塞等待
+// 队列腾出空间, 形成背压; 否则队列已满时立即返回 ErrQueueFull, 避免无限制
+// 地积压内存或 goroutine. shutdownMu 与 Close 互斥, 保证 Close 关闭 jobs 之
+// 后到达的调用只会看到 ErrConsumerClosed, 不会并发写入已关闭的 channel.
+func (c *BatchConsumer) enqueue(batch []UploadData) error {
+	c.shutdownMu.RLock()
+	defer c.shutdownMu.RUnlock()
+	if c.closed {
+		return ErrConsumerClosed
+	}
+	if c.blockOnFull {
+		c.jobs <- batch
+		return nil
+	}
+	select {
+	case c.jobs <- batch:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// worker 是上传 worker 池中的一个固定 goroutine, 串行处理拿到的每个批次,
+// 从而把并发 HTTP 请求数限制在 worker 数量以内.
+func (c *BatchConsumer) worker() {
+	defer c.workerWg.Done()
+	for batch := range c.jobs {
+		c.processBatch(batch)
+	}
+}
+
+func (c *BatchConsumer) processBatch(batch []UploadData) {
+	for _, buffer := range batch {
+		jdata, err := json.Marshal(buffer)
+		if err != nil {
+			continue
+		}
+		c.uploadWithRetry(buffer, parseTime(jdata))
+	}
+}
+
+// uploadWithRetry 按 RetryPolicy 重试上报一条数据, 5xx/网络错误/超时会以
+// 指数退避 + 全抖动继续重试, 4xx/签名错误等终态错误会立即放弃重试. 当
+// ctx 被取消(只由 Close 触发, FlushAll 不会取消 ctx)时, 会放弃重试以避免无谓阻塞. 重试耗尽
+// 或遇到终态错误后, 交给死信 Consumer 兜底.
+func (c *BatchConsumer) uploadWithRetry(buffer UploadData, params string) {
+	eventName := dataFromUploadData(buffer).EventName
+	for attempt := 0; attempt < c.retry.MaxAttempts; attempt++ {
+		start := time.Now()
+		statusCode, code, msg, err := c.send(c.ctx, params)
+		c.metrics.ObserveUploadLatency(time.Since(start).Seconds())
+		if statusCode == 200 && code == 10000 && err == nil {
+			c.metrics.ObserveUploadAttempt("success")
+			return
+		}
+		c.metrics.ObserveUploadAttempt("failure")
+		c.logger.Warn("事件上报失败", F("project", c.project), F("event_name", eventName), F("attempt", attempt+1), F("status_code", statusCode), F("msg", msg))
+
+		if !isRetryable(statusCode, code, err) {
+			c.metrics.IncEventsDropped("terminal_error")
+			c.deadLetterOrSpool(buffer)
+			return
+		}
+		if attempt == c.retry.MaxAttempts-1 {
+			c.logger.Error("事件上报重试耗尽", F("project", c.project), F("event_name", eventName), F("attempt", attempt+1))
+			c.metrics.IncEventsDropped("retries_exhausted")
+			c.deadLetterOrSpool(buffer)
+			return
+		}
+
+		select {
+		case <-c.ctx.Done():
+			c.metrics.IncEventsDropped("cancelled")
+			c.deadLetterOrSpool(buffer)
+			return
+		case <-time.After(c.retry.backoff(attempt)):
+		}
+	}
+}
+
+// deadLetterOrSpool 优先把放弃重试的数据交给 DeadLetter Consumer, 没有配置
+// 时退回到原有的 DiskSpool 兜底行为, 都未配置则只记录日志.
+func (c *BatchConsumer) deadLetterOrSpool(d UploadData) {
+	if c.deadLetter != nil {
+		if err := c.deadLetter.Add(dataFromUploadData(d)); err != nil {
+			c.logger.Error("死信投递失败", F("project", c.project), F("error", err.Error()))
+		}
+		return
+	}
+	c.spoolIfEnabled(d)
+}
+
+// dataFromUploadData 把已经编码过的 UploadData 还原成原始 Data, 以便转交
+// 给 DeadLetter Consumer 重新走一遍它自己的签名/入队逻辑.
+func dataFromUploadData(u UploadData) Data {
+	var d Data
+	_ = json.Unmarshal([]byte(u.Content), &d)
+	return d
+}
+
+// spoolIfEnabled 在配置了 DiskSpool 时, 把耗尽重试次数的数据落盘, 等待后台
+// 重放而不是直接丢弃; 未配置落盘队列时行为和原来一致, 仅记录日志.
+func (c *BatchConsumer) spoolIfEnabled(d UploadData) {
+	if c.spool == nil {
+		c.logger.Error("未配置 DeadLetter 或落盘队列, 数据丢弃", F("project", c.project), F("event_name", dataFromUploadData(d).EventName))
+		return
+	}
+	if err := c.spool.write(d); err != nil {
+		c.logger.Error("落盘失败, 数据丢失", F("project", c.project), F("error", err.Error()))
+	}
+}
+
+// replaySpool 在启动时及此后每隔 replayInterval 扫描已切割的历史段, 重新
+// 投递给接收端, 只有收到 200/code==10000 才删除对应段, 从而保证进程崩溃
+// 前落盘但尚未确认投递的事件不会丢失. bgStop 关闭时退出, 由 Close 等待.
+func (c *BatchConsumer) replaySpool() {
+	defer c.bgWg.Done()
+	ticker := time.NewTicker(replayInterval)
+	defer ticker.Stop()
+	c.replaySpoolOnce()
+	for {
+		select {
+		case <-c.bgStop:
+			return
+		case <-ticker.C:
+			c.replaySpoolOnce()
+		}
+	}
+}
+
+// replaySpoolOnce 先强制切割一次当前活跃文件, 使尚处于活跃文件中、还没有
+// 触发按大小切割的数据也能在 replayInterval 这个时间窗口内变为可扫描的
+// 历史段, 而不是只依赖 MaxSizeMB 这个大小维度的切割条件.
+func (c *BatchConsumer) replaySpoolOnce() {
+	if err := c.spool.rotate(); err != nil {
+		c.logger.Error("落盘切割失败", F("error", err.Error()))
+	}
+
+	segments, err := listSegments(c.spool.cfg.Dir)
+	if err != nil {
+		return
+	}
+	for _, segment := range segments {
+		items, err := readSegment(segment)
+		if err != nil {
+			continue
+		}
+		delivered := true
+		for _, item := range items {
+			jdata, err := json.Marshal(item)
+			if err != nil {
+				delivered = false
+				break
+			}
+			statusCode, code, _, err := c.send(c.ctx, parseTime(jdata))
+			if !(statusCode == 200 && code == 10000 && err == nil) {
+				delivered = false
+				break
+			}
+		}
+		if delivered {
+			os.Remove(segment)
+		}
+	}
+}
+
+const replayInterval = 5 * time.Second
+
 func (c *BatchConsumer) FlushAll() error {
 	for c.getCacheLength() > 0 || c.getBufferLength() > 0 {
 		if err := c.Flush(); err != nil {
@@ -250,17 +501,48 @@ func (c *BatchConsumer) FlushAll() error {
 	return nil
 }
 
+// Close 排空所有待上传的数据, 关闭任务队列并等待所有 worker 处理完已经
+// 入队的批次后才返回, 从而让关闭过程是确定性的. 首先停止 AutoFlush/落盘
+// 重放等后台协程, 避免它们在 jobs 被关闭后仍调用 enqueue 导致 panic; 随后
+// 在等待 worker 之前取消 ctx: 仍在重试退避中的 worker 会尽快放弃, 队列中
+// 尚未发出的请求也会因 ctx 已取消而立即失败, 这些数据和重试耗尽时一样交给
+// DeadLetter 或落盘队列, 都未配置时被丢弃.
 func (c *BatchConsumer) Close() error {
-	return c.FlushAll()
+	close(c.bgStop)
+	c.bgWg.Wait()
+
+	err := c.FlushAll()
+
+	c.shutdownMu.Lock()
+	c.closed = true
+	close(c.jobs)
+	c.shutdownMu.Unlock()
+
+	c.cancel()
+	c.workerWg.Wait()
+	if c.spool != nil {
+		if cerr := c.spool.close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
 }
 
 //上报数据
 
-func (c *BatchConsumer) send(data string) (statusCode int, Code int, Msg string, err error) {
+func (c *BatchConsumer) send(ctx context.Context, data string) (statusCode int, Code int, Msg string, err error) {
+	return postToServer(ctx, c.serverUrl, c.timeout, c.compress, data)
+}
+
+// postToServer 将一条已编码的上报数据通过 HTTP POST 发送到接收端, 供
+// BatchConsumer 以及其它需要直接投递数据的 Consumer (如 DiskLogConsumer) 复用.
+// ctx 被取消时请求会被中止. BatchConsumer 的 ctx 只由 Close 取消, 使 Close
+// 不必一直阻塞在一个正在进行中的请求上; FlushAll 不会取消 ctx.
+func postToServer(ctx context.Context, serverUrl string, timeout time.Duration, compress bool, data string) (statusCode int, Code int, Msg string, err error) {
 	var encodedData string
 	data = fmt.Sprintf("data=%s", Base64Encode(data))
 	var compressType = "gzip"
-	if c.compress {
+	if compress {
 		encodedData, err = encodeData(data)
 	} else {
 		encodedData = data
@@ -272,13 +554,13 @@ func (c *BatchConsumer) send(data string) (statusCode int, Code int, Msg string,
 	postData := bytes.NewBufferString(encodedData)
 
 	var resp *http.Response
-	req, _ := http.NewRequest("POST", c.serverUrl, postData)
+	req, _ := http.NewRequestWithContext(ctx, "POST", serverUrl, postData)
 	req.Header.Set("user-agent", "yga-go-sdk")
 	req.Header.Set("content-type", "application/x-www-form-urlencoded")
 	req.Header.Set("version", SdkVersion)
 	req.Header.Set("lib", LibName)
 	req.Header.Set("compress", compressType)
-	client := &http.Client{Timeout: c.timeout}
+	client := &http.Client{Timeout: timeout}
 	resp, err = client.Do(req)
 
 	if err != nil {
@@ -332,7 +614,7 @@ func (c *BatchConsumer) getCacheLength() int {
 func (c *BatchConsumer) MakeSign(d Data) string {
 	content, err := json.Marshal(d)
 	if err != nil {
-		log.Println("json编码失败", err)
+		c.logger.Error("json编码失败", F("error", err.Error()))
 		return ""
 	}
 	signstr := Md5([]byte(fmt.Sprintf("content=%s&project=%s&sk=%s&user=%s", CleanSpaces(string(content)), c.project, c.sk, c.user)))
@@ -0,0 +1,124 @@
+package ygadata
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type captureConsumer struct {
+	added []Data
+}
+
+func (c *captureConsumer) Add(d Data) error {
+	c.added = append(c.added, d)
+	return nil
+}
+
+func (c *captureConsumer) Flush() error { return nil }
+
+func (c *captureConsumer) Close() error { return nil }
+
+// TestUploadEventsRoutesToDeadLetterWhenQueueFull 验证队列已满且 BlockOnFull
+// 为 false 时, 待上传的批次会被转交给 DeadLetter 兜底, 而不是随 cacheBuffer
+// 一起被悄悄清空丢弃.
+func TestUploadEventsRoutesToDeadLetterWhenQueueFull(t *testing.T) {
+	dl := &captureConsumer{}
+	c := &BatchConsumer{
+		cacheCapacity: 10,
+		deadLetter:    dl,
+		jobs:          make(chan []UploadData, 1),
+		blockOnFull:   false,
+		metrics:       noopMetrics{},
+		logger:        stdLogger{},
+	}
+	// 先占满队列, 使接下来的 enqueue 必然失败
+	c.jobs <- []UploadData{}
+
+	item := UploadData{Auth: Auth{Project: "p"}, Content: `{"#event_name":"e"}`}
+	c.cacheBuffer = []UploadData{item}
+
+	err := c.uploadEvents()
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("uploadEvents() error = %v, want ErrQueueFull", err)
+	}
+	if len(dl.added) != 1 || dl.added[0].EventName != "e" {
+		t.Fatalf("deadLetter.added = %v, want one item with EventName \"e\"", dl.added)
+	}
+	if len(c.cacheBuffer) != 0 {
+		t.Fatalf("cacheBuffer = %v, want empty after uploadEvents", c.cacheBuffer)
+	}
+}
+
+// TestReplaySpoolOnceRotatesActiveFile 验证 replaySpoolOnce 会主动切割当前
+// 活跃文件, 使尚未达到 MaxSizeMB 的数据也能在一次重放扫描中被发现, 而不是
+// 无限期地停留在 spool-active.log 里, 直到进程崩溃时被悄悄丢失.
+func TestReplaySpoolOnceRotatesActiveFile(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := newDiskSpool(DiskSpoolConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("newDiskSpool() error = %v", err)
+	}
+	defer spool.close()
+
+	if err := spool.write(UploadData{Auth: Auth{Project: "p"}, Content: `{"#event_name":"e"}`}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	if segments, _ := listSegments(dir); len(segments) != 0 {
+		t.Fatalf("listSegments() = %v, want no segments before rotation", segments)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := &BatchConsumer{
+		spool:     spool,
+		serverUrl: "http://127.0.0.1:1/logagent",
+		timeout:   200 * time.Millisecond,
+		ctx:       ctx,
+		metrics:   noopMetrics{},
+		logger:    stdLogger{},
+	}
+	c.replaySpoolOnce()
+
+	if segments, _ := listSegments(dir); len(segments) != 1 {
+		t.Fatalf("listSegments() = %v, want 1 segment to have surfaced after replaySpoolOnce", segments)
+	}
+}
+
+// TestCloseConcurrentWithAddDoesNotPanic 验证 Close 与并发的 Add 之间不会出现
+// 向已关闭的 jobs channel 发送数据而 panic 的情况, 这是分析型 SDK 最常见的
+// 关停场景: 后台仍有 goroutine 在上报事件时, 调用方调用了 Close. 用
+// -race 运行可同时验证不存在数据竞争.
+func TestCloseConcurrentWithAddDoesNotPanic(t *testing.T) {
+	c, err := NewBatchConsumerWithBatchSize("http://127.0.0.1:1/logagent", "p", "u", "sk", 1)
+	if err != nil {
+		t.Fatalf("NewBatchConsumerWithBatchSize() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					_ = c.Add(Data{EventName: "e"})
+				}
+			}
+		}()
+	}
+
+	// Close 在并发 Add 持续打满队列时可能返回 ErrQueueFull, 这里只关心
+	// Close 与并发 Add/enqueue 之间不会 panic, 不对返回值做强校验.
+	time.Sleep(10 * time.Millisecond)
+	_ = c.Close()
+	close(stop)
+	wg.Wait()
+}
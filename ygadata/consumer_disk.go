@@ -0,0 +1,157 @@
+package ygadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DiskLogConfig 是 DiskLogConsumer 的配置, 复用上报端点相关字段并附加
+// 落盘队列的滚动策略.
+type DiskLogConfig struct {
+	ServerUrl     string // 接收端地址
+	Project       string // 项目ID
+	User          string // 用户名
+	Sk            string // 秘钥
+	Timeout       int    // 网络请求超时时间, 单位毫秒
+	Compress      bool   // 上报数据是否压缩
+	DrainInterval int    // 后台扫描并投递历史段的间隔, 单位秒, 默认 DefaultDrainInterval
+	DiskSpoolConfig
+}
+
+const (
+	DefaultDrainInterval = 5 // 默认后台投递扫描间隔, 单位秒
+)
+
+// DiskLogConsumer 将事件先追加写入本地滚动日志, 再由后台协程读取已切割的
+// 历史段并投递到接收端, 只有在收到 200 且 code==10000 的响应后才删除对应
+// 的历史段文件. 即使进程在投递完成前崩溃, 重启后后台协程也会在启动时立即
+// 重放遗留的历史段(不阻塞 Add), 从而获得 at-least-once 的投递语义.
+type DiskLogConsumer struct {
+	spool     *diskSpool
+	serverUrl string
+	project   string
+	user      string
+	sk        string
+	timeout   time.Duration
+	compress  bool
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+// NewDiskLogConsumer 创建一个磁盘队列 Consumer, 并重放目录下遗留的历史段.
+func NewDiskLogConsumer(config DiskLogConfig) (Consumer, error) {
+	if config.ServerUrl == "" {
+		return nil, errors.New("ServerUrl 不能为空")
+	}
+	spool, err := newDiskSpool(config.DiskSpoolConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeOut
+	}
+	interval := config.DrainInterval
+	if interval == 0 {
+		interval = DefaultDrainInterval
+	}
+
+	c := &DiskLogConsumer{
+		spool:     spool,
+		serverUrl: config.ServerUrl,
+		project:   config.Project,
+		user:      config.User,
+		sk:        config.Sk,
+		timeout:   time.Duration(timeout) * time.Millisecond,
+		compress:  config.Compress,
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+	}
+
+	go c.drainLoop(time.Duration(interval) * time.Second)
+
+	return c, nil
+}
+
+func (c *DiskLogConsumer) Add(d Data) error {
+	content, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	signstr := Md5([]byte(fmt.Sprintf("content=%s&project=%s&sk=%s&user=%s", CleanSpaces(string(content)), c.project, c.sk, c.user)))
+	return c.spool.write(UploadData{Auth{Project: c.project, User: c.user, Sign: signstr}, string(content)})
+}
+
+// Flush 对 DiskLogConsumer 而言是主动触发一次切割, 让当前缓冲尽快进入可
+// 投递的历史段, 真正的投递始终由后台协程完成.
+func (c *DiskLogConsumer) Flush() error {
+	return c.spool.rotate()
+}
+
+func (c *DiskLogConsumer) Close() error {
+	close(c.stopCh)
+	<-c.doneCh
+	return c.spool.close()
+}
+
+// drainLoop 定期扫描已切割的历史段并尝试投递, 投递成功后删除对应文件.
+// 启动时立即执行一轮, 即重放进程崩溃前遗留的历史段.
+func (c *DiskLogConsumer) drainLoop(interval time.Duration) {
+	defer close(c.doneCh)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	c.drainOnce()
+	for {
+		select {
+		case <-c.stopCh:
+			c.drainOnce()
+			return
+		case <-ticker.C:
+			c.drainOnce()
+		}
+	}
+}
+
+// drainOnce 先强制切割一次当前活跃文件, 使尚处于活跃文件中、还没有触发按
+// 大小切割的数据也能在 drainLoop 的时间窗口内变为可扫描的历史段, 而不是
+// 只依赖 MaxSizeMB 这个大小维度的切割条件.
+func (c *DiskLogConsumer) drainOnce() {
+	_ = c.spool.rotate()
+
+	segments, err := listSegments(c.spool.cfg.Dir)
+	if err != nil {
+		return
+	}
+	for _, segment := range segments {
+		items, err := readSegment(segment)
+		if err != nil {
+			continue
+		}
+		if c.deliverAll(items) {
+			os.Remove(segment)
+		}
+	}
+}
+
+// deliverAll 依次投递一个历史段中的所有数据, 只有全部投递成功才返回 true,
+// 从而保证只有确认送达的段才会被删除.
+func (c *DiskLogConsumer) deliverAll(items []UploadData) bool {
+	for _, item := range items {
+		jdata, err := json.Marshal(item)
+		if err != nil {
+			return false
+		}
+		params := parseTime(jdata)
+		statusCode, code, _, err := postToServer(context.Background(), c.serverUrl, c.timeout, c.compress, params)
+		if !(statusCode == 200 && code == 10000 && err == nil) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package ygadata
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDrainOnceRotatesActiveFile 验证 drainOnce 会主动切割当前活跃文件, 使
+// 尚未达到 MaxSizeMB 的数据也能在一次扫描中被发现, 而不是无限期地停留在
+// spool-active.log 里, 直到进程崩溃时被悄悄丢失.
+func TestDrainOnceRotatesActiveFile(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := newDiskSpool(DiskSpoolConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("newDiskSpool() error = %v", err)
+	}
+	defer spool.close()
+
+	if err := spool.write(UploadData{Auth: Auth{Project: "p"}, Content: `{"#event_name":"e"}`}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	if segments, _ := listSegments(dir); len(segments) != 0 {
+		t.Fatalf("listSegments() = %v, want no segments before rotation", segments)
+	}
+
+	c := &DiskLogConsumer{
+		spool:     spool,
+		serverUrl: "http://127.0.0.1:1/logagent",
+		timeout:   200 * time.Millisecond,
+	}
+	c.drainOnce()
+
+	if segments, _ := listSegments(dir); len(segments) != 1 {
+		t.Fatalf("listSegments() = %v, want 1 segment to have surfaced after drainOnce", segments)
+	}
+}
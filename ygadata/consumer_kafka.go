@@ -0,0 +1,81 @@
+package ygadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig 配置 KafkaConsumer 的生产者行为.
+type KafkaConfig struct {
+	Brokers      []string      // Kafka broker 地址列表
+	Topic        string        // 投递的目标 topic
+	BatchSize    int           // 批量发送条数, 默认 DefaultKafkaBatchSize
+	BatchTimeout time.Duration // 未凑够 BatchSize 时的最长等待时间, 默认 DefaultKafkaBatchTimeout
+}
+
+const (
+	DefaultKafkaBatchSize    = 100             // 默认批量发送条数
+	DefaultKafkaBatchTimeout = 1 * time.Second // 默认批量发送等待时间上限
+)
+
+// KafkaConsumer 把每条 Data 作为一条以 DeviceId/UserId 为 key 的消息发布到
+// 配置的 topic, 依赖 kafka-go 内置的异步生产者批量发送.
+type KafkaConsumer struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaConsumer 创建 KafkaConsumer.
+func NewKafkaConsumer(config KafkaConfig) (Consumer, error) {
+	if len(config.Brokers) == 0 {
+		return nil, errors.New("Brokers 不能为空")
+	}
+	if config.Topic == "" {
+		return nil, errors.New("Topic 不能为空")
+	}
+
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultKafkaBatchSize
+	}
+	batchTimeout := config.BatchTimeout
+	if batchTimeout <= 0 {
+		batchTimeout = DefaultKafkaBatchTimeout
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(config.Brokers...),
+		Topic:        config.Topic,
+		Balancer:     &kafka.Hash{},
+		BatchSize:    batchSize,
+		BatchTimeout: batchTimeout,
+		Async:        true,
+	}
+	return &KafkaConsumer{writer: writer}, nil
+}
+
+func (c *KafkaConsumer) Add(d Data) error {
+	value, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	key := d.UserId
+	if key == "" {
+		key = d.DeviceId
+	}
+	return c.writer.WriteMessages(context.Background(), kafka.Message{Key: []byte(key), Value: value})
+}
+
+// Flush 对 KafkaConsumer 而言是 no-op, 批量发送由 kafka-go 的异步生产者
+// 按 BatchSize/BatchTimeout 自行触发.
+func (c *KafkaConsumer) Flush() error {
+	return nil
+}
+
+// Close 等待所有在途消息发送完成后关闭底层连接.
+func (c *KafkaConsumer) Close() error {
+	return c.writer.Close()
+}
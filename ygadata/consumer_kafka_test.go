@@ -0,0 +1,29 @@
+package ygadata
+
+import "testing"
+
+func TestNewKafkaConsumerValidatesConfig(t *testing.T) {
+	if _, err := NewKafkaConsumer(KafkaConfig{Topic: "t"}); err == nil {
+		t.Error("NewKafkaConsumer() with empty Brokers, want error")
+	}
+	if _, err := NewKafkaConsumer(KafkaConfig{Brokers: []string{"localhost:9092"}}); err == nil {
+		t.Error("NewKafkaConsumer() with empty Topic, want error")
+	}
+}
+
+func TestNewKafkaConsumerAppliesDefaults(t *testing.T) {
+	c, err := NewKafkaConsumer(KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "t"})
+	if err != nil {
+		t.Fatalf("NewKafkaConsumer() error = %v", err)
+	}
+	kc := c.(*KafkaConsumer)
+	if kc.writer.BatchSize != DefaultKafkaBatchSize {
+		t.Errorf("BatchSize = %d, want %d", kc.writer.BatchSize, DefaultKafkaBatchSize)
+	}
+	if kc.writer.BatchTimeout != DefaultKafkaBatchTimeout {
+		t.Errorf("BatchTimeout = %v, want %v", kc.writer.BatchTimeout, DefaultKafkaBatchTimeout)
+	}
+	if err := c.Flush(); err != nil {
+		t.Errorf("Flush() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,73 @@
+package ygadata
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// LoggingConsumer 把事件以换行分隔的 JSON(NDJSON) 格式写入任意 io.Writer,
+// 常用于本地调试或离线归档, 不会向接收端发起任何网络请求.
+type LoggingConsumer struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// NewLoggingConsumer 基于一个任意的 io.Writer(例如 os.Stdout 或测试用的
+// bytes.Buffer) 创建 LoggingConsumer.
+func NewLoggingConsumer(w io.Writer) Consumer {
+	return &LoggingConsumer{writer: w}
+}
+
+// LoggingConfig 配置 NewLoggingConsumerWithRotation 底层的滚动策略, 字段含义
+// 与 lumberjack.Logger 一致.
+type LoggingConfig struct {
+	Filename   string // 日志文件路径
+	MaxSizeMB  int    // 单个日志文件达到该大小(MB)后触发切割, 默认 DefaultSpoolMaxSizeMB
+	MaxBackups int    // 最多保留的历史文件数, 0 表示不限制
+	MaxAgeDays int    // 历史文件最多保留天数, 0 表示不限制
+	Compress   bool   // 历史文件是否 gzip 压缩
+}
+
+// NewLoggingConsumerWithRotation 创建一个基于 lumberjack 滚动写入本地文件的
+// LoggingConsumer.
+func NewLoggingConsumerWithRotation(config LoggingConfig) Consumer {
+	maxSize := config.MaxSizeMB
+	if maxSize <= 0 {
+		maxSize = DefaultSpoolMaxSizeMB
+	}
+	return &LoggingConsumer{writer: &lumberjack.Logger{
+		Filename:   config.Filename,
+		MaxSize:    maxSize,
+		MaxBackups: config.MaxBackups,
+		MaxAge:     config.MaxAgeDays,
+		Compress:   config.Compress,
+	}}
+}
+
+func (c *LoggingConsumer) Add(d Data) error {
+	line, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, err = c.writer.Write(line)
+	return err
+}
+
+// Flush 对 LoggingConsumer 而言是 no-op, 每次 Add 都已经直接写入底层 Writer.
+func (c *LoggingConsumer) Flush() error {
+	return nil
+}
+
+func (c *LoggingConsumer) Close() error {
+	if closer, ok := c.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package ygadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLoggingConsumerAddWritesNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewLoggingConsumer(&buf)
+
+	if err := c.Add(Data{EventName: "e1"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := c.Add(Data{EventName: "e2"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := c.Flush(); err != nil {
+		t.Errorf("Flush() error = %v, want nil", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close() error = %v, want nil for a non-closer writer", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	for i, want := range []string{"e1", "e2"} {
+		var d Data
+		if err := json.Unmarshal([]byte(lines[i]), &d); err != nil {
+			t.Fatalf("Unmarshal(%q) error = %v", lines[i], err)
+		}
+		if d.EventName != want {
+			t.Errorf("lines[%d].EventName = %q, want %q", i, d.EventName, want)
+		}
+	}
+}
+
+type closableBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closableBuffer) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestLoggingConsumerCloseClosesUnderlyingWriter(t *testing.T) {
+	cb := &closableBuffer{}
+	c := NewLoggingConsumer(cb)
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !cb.closed {
+		t.Error("Close() did not close the underlying io.Closer")
+	}
+}
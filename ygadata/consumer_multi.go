@@ -0,0 +1,46 @@
+package ygadata
+
+import "errors"
+
+// MultiConsumer 把 Add/Flush/Close 同时转发给一组 Consumer, 常用于在上报到
+// 接收端的同时镜像写入 Kafka 或磁盘等系统做二次处理. 每个方法都会调用全部
+// 下游 Consumer, 并用 errors.Join 把遇到的错误聚合后一并返回, 而不是在第一
+// 个错误处中断.
+type MultiConsumer struct {
+	consumers []Consumer
+}
+
+// NewMultiConsumer 创建一个扇出到多个 Consumer 的 MultiConsumer.
+func NewMultiConsumer(consumers ...Consumer) Consumer {
+	return &MultiConsumer{consumers: consumers}
+}
+
+func (m *MultiConsumer) Add(d Data) error {
+	var errs []error
+	for _, c := range m.consumers {
+		if err := c.Add(d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *MultiConsumer) Flush() error {
+	var errs []error
+	for _, c := range m.consumers {
+		if err := c.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *MultiConsumer) Close() error {
+	var errs []error
+	for _, c := range m.consumers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
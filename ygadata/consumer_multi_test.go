@@ -0,0 +1,44 @@
+package ygadata
+
+import (
+	"errors"
+	"testing"
+)
+
+type erroringConsumer struct {
+	err   error
+	calls int
+}
+
+func (c *erroringConsumer) Add(d Data) error { c.calls++; return c.err }
+func (c *erroringConsumer) Flush() error     { c.calls++; return c.err }
+func (c *erroringConsumer) Close() error     { c.calls++; return c.err }
+
+func TestMultiConsumerFansOutToAllConsumers(t *testing.T) {
+	a := &captureConsumer{}
+	b := &captureConsumer{}
+	m := NewMultiConsumer(a, b)
+
+	if err := m.Add(Data{EventName: "e"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if len(a.added) != 1 || len(b.added) != 1 {
+		t.Fatalf("a.added = %v, b.added = %v, want one event in each", a.added, b.added)
+	}
+}
+
+func TestMultiConsumerJoinsErrorsWithoutShortCircuiting(t *testing.T) {
+	errA := errors.New("consumer a failed")
+	errB := errors.New("consumer b failed")
+	a := &erroringConsumer{err: errA}
+	b := &erroringConsumer{err: errB}
+	m := NewMultiConsumer(a, b)
+
+	err := m.Add(Data{EventName: "e"})
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("Add() error = %v, want it to wrap both %v and %v", err, errA, errB)
+	}
+	if a.calls != 1 || b.calls != 1 {
+		t.Fatalf("a.calls = %d, b.calls = %d, want both consumers called despite the first erroring", a.calls, b.calls)
+	}
+}
@@ -0,0 +1,215 @@
+package ygadata
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiskSpoolConfig 配置落盘队列的滚动策略, 用于在进程崩溃或上报失败时
+// 持久化事件, 避免内存中的数据被无限制丢弃.
+type DiskSpoolConfig struct {
+	Dir       string // 日志文件存放目录
+	MaxSizeMB int    // 单个日志文件达到该大小(MB)后触发切割, 默认 DefaultSpoolMaxSizeMB
+	Compress  bool   // 历史切割文件是否 gzip 压缩
+}
+
+const (
+	DefaultSpoolMaxSizeMB = 100 // 默认单文件切割阈值, 单位 MB
+	spoolActiveName       = "spool-active.log"
+	spoolSegmentExt       = ".log"
+	spoolSegmentGzExt     = ".log.gz"
+)
+
+// diskSpool 是一个按大小切割的本地追加日志, 每行是一条 UploadData 的 JSON.
+// 写入端持续追加到 spool-active.log, 达到阈值后切割为带时间戳的历史文件,
+// 由后台协程负责读取并投递, 投递成功后删除.
+type diskSpool struct {
+	cfg      DiskSpoolConfig
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	rotateAt time.Time
+}
+
+func newDiskSpool(cfg DiskSpoolConfig) (*diskSpool, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("DiskSpoolConfig.Dir 不能为空")
+	}
+	if cfg.MaxSizeMB <= 0 {
+		cfg.MaxSizeMB = DefaultSpoolMaxSizeMB
+	}
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &diskSpool{cfg: cfg}
+	if err := s.openActive(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *diskSpool) activePath() string {
+	return filepath.Join(s.cfg.Dir, spoolActiveName)
+}
+
+func (s *diskSpool) openActive() error {
+	f, err := os.OpenFile(s.activePath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.size = info.Size()
+	s.rotateAt = time.Now()
+	return nil
+}
+
+// write 追加一条待投递的数据, 超过大小阈值时触发切割.
+func (s *diskSpool) write(d UploadData) error {
+	line, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.file.Write(line); err != nil {
+		return err
+	}
+	s.size += int64(len(line))
+
+	if s.size >= int64(s.cfg.MaxSizeMB)*1024*1024 {
+		return s.rotateLocked()
+	}
+	return nil
+}
+
+// rotateLocked 将当前活跃文件重命名为带时间戳的历史段, 并开启一个新的活跃文件.
+// 调用方需持有 s.mutex.
+func (s *diskSpool) rotateLocked() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	segment := filepath.Join(s.cfg.Dir, fmt.Sprintf("spool-%d%s", time.Now().UnixNano(), spoolSegmentExt))
+	if err := os.Rename(s.activePath(), segment); err != nil {
+		return err
+	}
+	if s.cfg.Compress {
+		if err := gzipSegment(segment); err != nil {
+			log.Println("滚动日志压缩失败", err)
+		}
+	}
+	return s.openActive()
+}
+
+// rotate 主动触发一次切割, 用于后台定时切割长期未达到大小阈值的活跃文件.
+func (s *diskSpool) rotate() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.size == 0 {
+		return nil
+	}
+	return s.rotateLocked()
+}
+
+func (s *diskSpool) close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.file.Close()
+}
+
+func gzipSegment(path string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	gzPath := strings.TrimSuffix(path, spoolSegmentExt) + spoolSegmentGzExt
+	f, err := os.Create(gzPath)
+	if err != nil {
+		return err
+	}
+	gw := gzip.NewWriter(f)
+	if _, err := gw.Write(raw); err != nil {
+		gw.Close()
+		f.Close()
+		return err
+	}
+	gw.Close()
+	f.Close()
+	return os.Remove(path)
+}
+
+// listSegments 返回按创建时间升序排列的已切割历史段(不含活跃文件).
+func listSegments(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var segments []string
+	for _, e := range entries {
+		name := e.Name()
+		if name == spoolActiveName {
+			continue
+		}
+		if strings.HasSuffix(name, spoolSegmentExt) || strings.HasSuffix(name, spoolSegmentGzExt) {
+			segments = append(segments, filepath.Join(dir, name))
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i] < segments[j]
+	})
+	return segments, nil
+}
+
+// readSegment 读取一个历史段中的全部 UploadData, 透明处理 gzip 压缩.
+func readSegment(path string) ([]UploadData, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var scanner *bufio.Scanner
+	if strings.HasSuffix(path, spoolSegmentGzExt) {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		scanner = bufio.NewScanner(gr)
+	} else {
+		scanner = bufio.NewScanner(f)
+	}
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var items []UploadData
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var d UploadData
+		if err := json.Unmarshal(line, &d); err != nil {
+			log.Println("落盘数据解析失败, 已跳过", err)
+			continue
+		}
+		items = append(items, d)
+	}
+	return items, scanner.Err()
+}
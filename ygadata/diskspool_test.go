@@ -0,0 +1,94 @@
+package ygadata
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskSpoolRotateAndReadSegment(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newDiskSpool(DiskSpoolConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("newDiskSpool() error = %v", err)
+	}
+	defer s.close()
+
+	want := UploadData{Auth: Auth{Project: "p", User: "u", Sign: "sign"}, Content: `{"#event_name":"test"}`}
+	if err := s.write(want); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	if err := s.rotate(); err != nil {
+		t.Fatalf("rotate() error = %v", err)
+	}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		t.Fatalf("listSegments() error = %v", err)
+	}
+	if len(segments) != 1 {
+		t.Fatalf("listSegments() = %v, want 1 segment", segments)
+	}
+
+	items, err := readSegment(segments[0])
+	if err != nil {
+		t.Fatalf("readSegment() error = %v", err)
+	}
+	if len(items) != 1 || items[0] != want {
+		t.Fatalf("readSegment() = %v, want [%v]", items, want)
+	}
+}
+
+func TestDiskSpoolRotateSkipsEmptyActiveFile(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newDiskSpool(DiskSpoolConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("newDiskSpool() error = %v", err)
+	}
+	defer s.close()
+
+	if err := s.rotate(); err != nil {
+		t.Fatalf("rotate() error = %v", err)
+	}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		t.Fatalf("listSegments() error = %v", err)
+	}
+	if len(segments) != 0 {
+		t.Fatalf("listSegments() = %v, want no segments for an empty active file", segments)
+	}
+}
+
+func TestDiskSpoolCompressSegment(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newDiskSpool(DiskSpoolConfig{Dir: dir, Compress: true})
+	if err != nil {
+		t.Fatalf("newDiskSpool() error = %v", err)
+	}
+	defer s.close()
+
+	want := UploadData{Auth: Auth{Project: "p", User: "u", Sign: "sign"}, Content: `{"#event_name":"test"}`}
+	if err := s.write(want); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	if err := s.rotate(); err != nil {
+		t.Fatalf("rotate() error = %v", err)
+	}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		t.Fatalf("listSegments() error = %v", err)
+	}
+	if len(segments) != 1 || filepath.Ext(segments[0]) != ".gz" {
+		t.Fatalf("listSegments() = %v, want a single .gz segment", segments)
+	}
+
+	items, err := readSegment(segments[0])
+	if err != nil {
+		t.Fatalf("readSegment() error = %v", err)
+	}
+	if len(items) != 1 || items[0] != want {
+		t.Fatalf("readSegment() = %v, want [%v]", items, want)
+	}
+}
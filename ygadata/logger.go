@@ -0,0 +1,73 @@
+package ygadata
+
+import (
+	"log"
+
+	"go.uber.org/zap"
+)
+
+// Field 是一个结构化日志字段, 用来在不暴露具体日志库类型的情况下传递
+// project、event_name、attempt、status_code 这类上下文信息.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F 构造一个 Field, 用法类似 ygadata.F("project", project).
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Logger 是 SDK 内部使用的结构化日志接口. 默认实现基于标准库 log 包, 可以
+// 通过 BatchConfig.Logger 或 YgaAnalytics.SetLogger 替换为 NewZapLogger 等
+// 结构化实现.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+}
+
+// stdLogger 是零配置时使用的默认 Logger, 行为上与旧版散落各处的
+// log.Println 调用保持一致.
+type stdLogger struct{}
+
+func (stdLogger) Debug(msg string, fields ...Field) { stdLogger{}.print("DEBUG", msg, fields) }
+func (stdLogger) Info(msg string, fields ...Field)  { stdLogger{}.print("INFO", msg, fields) }
+func (stdLogger) Warn(msg string, fields ...Field)  { stdLogger{}.print("WARN", msg, fields) }
+func (stdLogger) Error(msg string, fields ...Field) { stdLogger{}.print("ERROR", msg, fields) }
+
+func (stdLogger) print(level, msg string, fields []Field) {
+	log.Println(level, msg, fieldsToArgs(fields))
+}
+
+func fieldsToArgs(fields []Field) []interface{} {
+	args := make([]interface{}, 0, len(fields)*2)
+	for _, f := range fields {
+		args = append(args, f.Key, f.Value)
+	}
+	return args
+}
+
+// zapLogger 把 Logger 适配到 go.uber.org/zap, 作为一个可选的结构化日志实现.
+type zapLogger struct {
+	l *zap.Logger
+}
+
+// NewZapLogger 用一个已经配置好的 *zap.Logger 构造一个 Logger.
+func NewZapLogger(l *zap.Logger) Logger {
+	return &zapLogger{l: l}
+}
+
+func (z *zapLogger) Debug(msg string, fields ...Field) { z.l.Debug(msg, toZapFields(fields)...) }
+func (z *zapLogger) Info(msg string, fields ...Field)  { z.l.Info(msg, toZapFields(fields)...) }
+func (z *zapLogger) Warn(msg string, fields ...Field)  { z.l.Warn(msg, toZapFields(fields)...) }
+func (z *zapLogger) Error(msg string, fields ...Field) { z.l.Error(msg, toZapFields(fields)...) }
+
+func toZapFields(fields []Field) []zap.Field {
+	zfields := make([]zap.Field, 0, len(fields))
+	for _, f := range fields {
+		zfields = append(zfields, zap.Any(f.Key, f.Value))
+	}
+	return zfields
+}
@@ -0,0 +1,51 @@
+package ygadata
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFieldsToArgs(t *testing.T) {
+	args := fieldsToArgs([]Field{F("project", "p"), F("attempt", 1)})
+	want := []interface{}{"project", "p", "attempt", 1}
+	if len(args) != len(want) {
+		t.Fatalf("fieldsToArgs() = %v, want %v", args, want)
+	}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Fatalf("fieldsToArgs()[%d] = %v, want %v", i, args[i], want[i])
+		}
+	}
+}
+
+func TestZapLoggerLevels(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	l := NewZapLogger(zap.New(core))
+
+	l.Debug("debug msg", F("k", "v"))
+	l.Info("info msg")
+	l.Warn("warn msg")
+	l.Error("error msg")
+
+	entries := logs.All()
+	if len(entries) != 4 {
+		t.Fatalf("got %d log entries, want 4", len(entries))
+	}
+	if entries[0].Message != "debug msg" || entries[0].Level != zap.DebugLevel {
+		t.Errorf("entries[0] = %+v, want debug msg at debug level", entries[0])
+	}
+	if entries[0].ContextMap()["k"] != "v" {
+		t.Errorf("entries[0] context = %v, want k=v", entries[0].ContextMap())
+	}
+	if entries[1].Message != "info msg" || entries[1].Level != zap.InfoLevel {
+		t.Errorf("entries[1] = %+v, want info msg at info level", entries[1])
+	}
+	if entries[2].Message != "warn msg" || entries[2].Level != zap.WarnLevel {
+		t.Errorf("entries[2] = %+v, want warn msg at warn level", entries[2])
+	}
+	if entries[3].Message != "error msg" || entries[3].Level != zap.ErrorLevel {
+		t.Errorf("entries[3] = %+v, want error msg at error level", entries[3])
+	}
+}
@@ -0,0 +1,107 @@
+package ygadata
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// MetricsCollector 是 SDK 对外暴露的指标采集接口, 默认实现是一个什么都不
+// 做的空实现, 以保证零配置时不引入任何额外开销或依赖.
+type MetricsCollector interface {
+	// IncEventsEnqueued 在一条事件成功进入 Consumer 时调用
+	IncEventsEnqueued()
+	// IncEventsDropped 在一条事件因为某种原因未能上报成功时调用, 每条事件调用一次,
+	// reason 例如 "invalid_format"、"retries_exhausted"、"queue_full"
+	IncEventsDropped(reason string)
+	// ObserveUploadAttempt 在每次上报尝试结束后调用, result 为 "success" 或 "failure"
+	ObserveUploadAttempt(result string)
+	// ObserveUploadLatency 记录单次上报请求的耗时, 单位秒
+	ObserveUploadLatency(seconds float64)
+	// SetBufferSize 汇报当前内存缓冲区(buffer)的长度
+	SetBufferSize(n int)
+	// SetCacheSize 汇报当前缓存区(cacheBuffer)的长度
+	SetCacheSize(n int)
+}
+
+// noopMetrics 是零配置时使用的默认 MetricsCollector, 所有方法都是空操作.
+type noopMetrics struct{}
+
+func (noopMetrics) IncEventsEnqueued()           {}
+func (noopMetrics) IncEventsDropped(string)      {}
+func (noopMetrics) ObserveUploadAttempt(string)  {}
+func (noopMetrics) ObserveUploadLatency(float64) {}
+func (noopMetrics) SetBufferSize(int)            {}
+func (noopMetrics) SetCacheSize(int)             {}
+
+// PrometheusMetrics 是基于 client_golang 的默认 MetricsCollector 实现, 所有
+// 指标注册到调用方提供的 *prometheus.Registry 上, 便于与已有的 Prometheus
+// 采集管线共用一个 Registry.
+type PrometheusMetrics struct {
+	eventsEnqueued prometheus.Counter
+	eventsDropped  *prometheus.CounterVec
+	uploadAttempts *prometheus.CounterVec
+	uploadLatency  prometheus.Histogram
+	bufferSize     prometheus.Gauge
+	cacheSize      prometheus.Gauge
+}
+
+// NewPrometheusMetrics 创建并注册一组默认指标:
+//
+//	yga_events_enqueued_total
+//	yga_events_dropped_total{reason}
+//	yga_upload_attempts_total{result}
+//	yga_upload_latency_seconds
+//	yga_buffer_size
+//	yga_cache_size
+func NewPrometheusMetrics(reg *prometheus.Registry) *PrometheusMetrics {
+	m := &PrometheusMetrics{
+		eventsEnqueued: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "yga_events_enqueued_total",
+			Help: "事件成功进入 Consumer 的总数",
+		}),
+		eventsDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "yga_events_dropped_total",
+			Help: "事件未能上报成功被丢弃的总数, 按原因区分",
+		}, []string{"reason"}),
+		uploadAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "yga_upload_attempts_total",
+			Help: "上报请求的尝试次数, 按结果区分",
+		}, []string{"result"}),
+		uploadLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "yga_upload_latency_seconds",
+			Help:    "单次上报请求的耗时",
+			Buckets: prometheus.DefBuckets,
+		}),
+		bufferSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "yga_buffer_size",
+			Help: "当前内存缓冲区(buffer)的长度",
+		}),
+		cacheSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "yga_cache_size",
+			Help: "当前缓存区(cacheBuffer)的长度",
+		}),
+	}
+	reg.MustRegister(m.eventsEnqueued, m.eventsDropped, m.uploadAttempts, m.uploadLatency, m.bufferSize, m.cacheSize)
+	return m
+}
+
+func (m *PrometheusMetrics) IncEventsEnqueued() {
+	m.eventsEnqueued.Inc()
+}
+
+func (m *PrometheusMetrics) IncEventsDropped(reason string) {
+	m.eventsDropped.WithLabelValues(reason).Inc()
+}
+
+func (m *PrometheusMetrics) ObserveUploadAttempt(result string) {
+	m.uploadAttempts.WithLabelValues(result).Inc()
+}
+
+func (m *PrometheusMetrics) ObserveUploadLatency(seconds float64) {
+	m.uploadLatency.Observe(seconds)
+}
+
+func (m *PrometheusMetrics) SetBufferSize(n int) {
+	m.bufferSize.Set(float64(n))
+}
+
+func (m *PrometheusMetrics) SetCacheSize(n int) {
+	m.cacheSize.Set(float64(n))
+}
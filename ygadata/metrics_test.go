@@ -0,0 +1,60 @@
+package ygadata
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestPrometheusMetricsRecordsValues(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewPrometheusMetrics(reg)
+
+	m.IncEventsEnqueued()
+	m.IncEventsEnqueued()
+	m.IncEventsDropped("queue_full")
+	m.ObserveUploadAttempt("success")
+	m.ObserveUploadLatency(0.5)
+	m.SetBufferSize(3)
+	m.SetCacheSize(7)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	metrics := make(map[string][]*dto.Metric)
+	for _, fam := range families {
+		metrics[fam.GetName()] = fam.GetMetric()
+	}
+
+	if got := metrics["yga_events_enqueued_total"][0].GetCounter().GetValue(); got != 2 {
+		t.Errorf("yga_events_enqueued_total = %v, want 2", got)
+	}
+	if got := metrics["yga_events_dropped_total"][0].GetCounter().GetValue(); got != 1 {
+		t.Errorf("yga_events_dropped_total = %v, want 1", got)
+	}
+	if got := metrics["yga_upload_attempts_total"][0].GetCounter().GetValue(); got != 1 {
+		t.Errorf("yga_upload_attempts_total = %v, want 1", got)
+	}
+	if got := metrics["yga_upload_latency_seconds"][0].GetHistogram().GetSampleCount(); got != 1 {
+		t.Errorf("yga_upload_latency_seconds sample count = %v, want 1", got)
+	}
+	if got := metrics["yga_buffer_size"][0].GetGauge().GetValue(); got != 3 {
+		t.Errorf("yga_buffer_size = %v, want 3", got)
+	}
+	if got := metrics["yga_cache_size"][0].GetGauge().GetValue(); got != 7 {
+		t.Errorf("yga_cache_size = %v, want 7", got)
+	}
+}
+
+func TestNoopMetricsDoesNotPanic(t *testing.T) {
+	var m MetricsCollector = noopMetrics{}
+	m.IncEventsEnqueued()
+	m.IncEventsDropped("reason")
+	m.ObserveUploadAttempt("failure")
+	m.ObserveUploadLatency(1.2)
+	m.SetBufferSize(1)
+	m.SetCacheSize(1)
+}
@@ -0,0 +1,87 @@
+package ygadata
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy 控制上报失败后的重试行为: 指数退避 + 抖动, 即
+// sleep = rand(cap*(1-JitterFraction), cap), cap = min(MaxBackoff, InitialBackoff*Multiplier^attempt).
+// JitterFraction 为 1(默认)时就是标准的全抖动(full jitter): sleep = rand(0, cap).
+type RetryPolicy struct {
+	MaxAttempts    int           // 最大尝试次数(含首次), 默认 DefaultMaxAttempts
+	InitialBackoff time.Duration // 首次重试前的基础等待时间, 默认 DefaultInitialBackoff
+	MaxBackoff     time.Duration // 单次等待的上限, 默认 DefaultMaxBackoff
+	Multiplier     float64       // 每次重试的退避倍数, 默认 DefaultBackoffMultiplier
+	JitterFraction float64       // 抖动比例, 取值 (0, 1], 默认 DefaultJitterFraction(=1, 即全抖动)
+}
+
+const (
+	DefaultMaxAttempts       = 3                      // 默认最大尝试次数, 与旧版重试 3 次保持一致
+	DefaultInitialBackoff    = 500 * time.Millisecond // 默认首次重试基础等待时间
+	DefaultMaxBackoff        = 30 * time.Second       // 默认单次等待上限
+	DefaultBackoffMultiplier = 2.0                    // 默认退避倍数
+	DefaultJitterFraction    = 1.0                    // 默认抖动比例, 对应全抖动算法
+)
+
+// withDefaults 对未设置的字段填充默认值, 保持零配置时行为不变.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultMaxBackoff
+	}
+	if p.Multiplier <= 0 {
+		p.Multiplier = DefaultBackoffMultiplier
+	}
+	if p.JitterFraction <= 0 || p.JitterFraction > 1 {
+		p.JitterFraction = DefaultJitterFraction
+	}
+	return p
+}
+
+// backoff 返回第 attempt(从 0 开始) 次重试前应等待的时长. JitterFraction 控制
+// 抖动覆盖的比例: 为 1 时是标准的全抖动算法, 避免大量客户端在同一时刻集中
+// 重试造成雷鸣群体效应; 小于 1 时保留 (1-JitterFraction) 的固定等待时间,
+// 只在剩余部分上抖动.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoffCap := float64(p.InitialBackoff)
+	for i := 0; i < attempt; i++ {
+		backoffCap *= p.Multiplier
+		if backoffCap > float64(p.MaxBackoff) {
+			backoffCap = float64(p.MaxBackoff)
+			break
+		}
+	}
+	jitterRange := backoffCap * p.JitterFraction
+	base := backoffCap - jitterRange
+	return time.Duration(base) + time.Duration(rand.Int63n(int64(jitterRange)+1))
+}
+
+// isRetryable 依据 HTTP 状态码/业务 code/本地错误判断本次上报是否值得重试:
+// 5xx、网络错误、超时视为可重试; 4xx 以及签名等业务校验失败视为终态, 重试
+// 也无法成功, 直接进入死信流程.
+func isRetryable(statusCode, code int, err error) bool {
+	if err != nil {
+		// client.Do 返回的错误既包含网络错误也包含超时, 两者都值得重试
+		return true
+	}
+	if statusCode == 0 {
+		return true
+	}
+	if statusCode >= 500 {
+		return true
+	}
+	if statusCode >= 400 && statusCode < 500 {
+		return false
+	}
+	if statusCode == 200 && code != 10000 {
+		// 业务层明确拒绝(如签名错误), 重试没有意义
+		return false
+	}
+	return false
+}
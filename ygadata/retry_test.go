@@ -0,0 +1,89 @@
+package ygadata
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyWithDefaults(t *testing.T) {
+	p := RetryPolicy{}.withDefaults()
+	if p.MaxAttempts != DefaultMaxAttempts {
+		t.Errorf("MaxAttempts = %d, want %d", p.MaxAttempts, DefaultMaxAttempts)
+	}
+	if p.InitialBackoff != DefaultInitialBackoff {
+		t.Errorf("InitialBackoff = %v, want %v", p.InitialBackoff, DefaultInitialBackoff)
+	}
+	if p.MaxBackoff != DefaultMaxBackoff {
+		t.Errorf("MaxBackoff = %v, want %v", p.MaxBackoff, DefaultMaxBackoff)
+	}
+	if p.Multiplier != DefaultBackoffMultiplier {
+		t.Errorf("Multiplier = %v, want %v", p.Multiplier, DefaultBackoffMultiplier)
+	}
+	if p.JitterFraction != DefaultJitterFraction {
+		t.Errorf("JitterFraction = %v, want %v", p.JitterFraction, DefaultJitterFraction)
+	}
+
+	custom := RetryPolicy{MaxAttempts: 5}.withDefaults()
+	if custom.MaxAttempts != 5 {
+		t.Errorf("MaxAttempts = %d, want 5", custom.MaxAttempts)
+	}
+	if custom.InitialBackoff != DefaultInitialBackoff {
+		t.Errorf("InitialBackoff = %v, want %v", custom.InitialBackoff, DefaultInitialBackoff)
+	}
+}
+
+func TestRetryPolicyBackoffRespectsCap(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 1 * time.Second,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+	}
+	for attempt := 0; attempt < 10; attempt++ {
+		d := p.backoff(attempt)
+		if d < 0 || d > p.MaxBackoff {
+			t.Fatalf("backoff(%d) = %v, want within [0, %v]", attempt, d, p.MaxBackoff)
+		}
+	}
+}
+
+func TestRetryPolicyBackoffJitterFraction(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 1 * time.Second,
+		MaxBackoff:     1 * time.Second,
+		Multiplier:     2,
+		JitterFraction: 0.5,
+	}.withDefaults()
+
+	want := p.MaxBackoff / 2
+	for i := 0; i < 20; i++ {
+		d := p.backoff(0)
+		if d < want || d > p.MaxBackoff {
+			t.Fatalf("backoff(0) = %v, want within [%v, %v]", d, want, p.MaxBackoff)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		name       string
+		statusCode int
+		code       int
+		err        error
+		want       bool
+	}{
+		{"network error", 0, 0, errors.New("dial tcp: timeout"), true},
+		{"status code zero", 0, 0, nil, true},
+		{"server error", 503, 0, nil, true},
+		{"client error", 404, 0, nil, false},
+		{"business rejection", 200, -1, nil, false},
+		{"success", 200, 10000, nil, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isRetryable(c.statusCode, c.code, c.err); got != c.want {
+				t.Errorf("isRetryable(%d, %d, %v) = %v, want %v", c.statusCode, c.code, c.err, got, c.want)
+			}
+		})
+	}
+}
@@ -63,6 +63,16 @@ func checkPattern(name []byte) bool {
 	return keyPattern.Match(name)
 }
 
+// isNumber 判断一个属性值是否为数字类型, 用于 UserAdd 的入参校验.
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	default:
+		return false
+	}
+}
+
 func parseTime(input []byte) string {
 	var re = regexp.MustCompile(`(((\d{4}-\d{2}-\d{2})T(\d{2}:\d{2}:\d{2})(?:\.(\d{3}))\d+)?)(Z|[\+-]\d{2}:\d{2})`)
 	var substitution = "$3 $4.$5"
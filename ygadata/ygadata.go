@@ -7,11 +7,16 @@ import (
 )
 
 const (
-	Track      = "track"
-	User       = "user"
-	UserSet    = "user_set"
-	SdkVersion = "1.6.0"
-	LibName    = "Golang"
+	Track       = "track"
+	User        = "user"
+	UserSet     = "user_set"
+	UserSetOnce = "user_setOnce"
+	UserAdd     = "user_add"
+	UserAppend  = "user_append"
+	UserUnset   = "user_unset"
+	UserDel     = "user_del"
+	SdkVersion  = "1.6.0"
+	LibName     = "Golang"
 )
 
 // Data 数据信息
@@ -39,6 +44,8 @@ type YgaAnalytics struct {
 	superProperties        map[string]interface{}
 	mutex                  *sync.RWMutex
 	dynamicSuperProperties func() map[string]interface{}
+	metrics                MetricsCollector
+	logger                 Logger
 }
 
 // New 初始化 YgaAnalytics
@@ -46,7 +53,23 @@ func New(c Consumer) YgaAnalytics {
 	return YgaAnalytics{
 		consumer:        c,
 		superProperties: make(map[string]interface{}),
-		mutex:           new(sync.RWMutex)}
+		mutex:           new(sync.RWMutex),
+		metrics:         noopMetrics{},
+		logger:          stdLogger{}}
+}
+
+// SetMetricsCollector 设置指标采集器, 默认不采集任何指标
+func (yga *YgaAnalytics) SetMetricsCollector(m MetricsCollector) {
+	yga.mutex.Lock()
+	yga.metrics = m
+	yga.mutex.Unlock()
+}
+
+// SetLogger 设置结构化日志实现, 默认使用标准库 log 包
+func (yga *YgaAnalytics) SetLogger(l Logger) {
+	yga.mutex.Lock()
+	yga.logger = l
+	yga.mutex.Unlock()
 }
 
 // GetSuperProperties 返回公共事件属性
@@ -116,6 +139,44 @@ func (yga *YgaAnalytics) UserSet(DeviceId, UserId, AppName, Platform, Time strin
 	return yga.user(DeviceId, UserId, AppName, Platform, Time, Sever, UserSet, properties)
 }
 
+// UserSetOnce 设置用户属性. 与 UserSet 不同的是, 如果同名属性已存在，则忽略，
+// 只有不存在的属性才会被写入.
+func (yga *YgaAnalytics) UserSetOnce(DeviceId, UserId, AppName, Platform, Time string, Sever int, properties map[string]interface{}) error {
+	return yga.user(DeviceId, UserId, AppName, Platform, Time, Sever, UserSetOnce, properties)
+}
+
+// UserAdd 为用户的数值类型属性进行累加, properties 的每个值都必须是数字类型.
+func (yga *YgaAnalytics) UserAdd(DeviceId, UserId, AppName, Platform, Time string, Sever int, properties map[string]interface{}) error {
+	for k, v := range properties {
+		if !isNumber(v) {
+			return errors.New("invalid value for UserAdd property " + k + ": must be a number")
+		}
+	}
+	return yga.user(DeviceId, UserId, AppName, Platform, Time, Sever, UserAdd, properties)
+}
+
+// UserAppend 向用户的列表类型属性追加元素.
+func (yga *YgaAnalytics) UserAppend(DeviceId, UserId, AppName, Platform, Time string, Sever int, properties map[string]interface{}) error {
+	return yga.user(DeviceId, UserId, AppName, Platform, Time, Sever, UserAppend, properties)
+}
+
+// UserUnset 删除用户的指定属性, keys 为待删除的属性名列表.
+func (yga *YgaAnalytics) UserUnset(DeviceId, UserId, AppName, Platform, Time string, Sever int, keys []string) error {
+	if len(keys) == 0 {
+		return errors.New("invalid params for " + UserUnset + ": keys is empty")
+	}
+	properties := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		properties[k] = ""
+	}
+	return yga.user(DeviceId, UserId, AppName, Platform, Time, Sever, UserUnset, properties)
+}
+
+// UserDelete 删除整个用户.
+func (yga *YgaAnalytics) UserDelete(DeviceId, UserId, AppName, Platform, Time string, Sever int) error {
+	return yga.user(DeviceId, UserId, AppName, Platform, Time, Sever, UserDel, map[string]interface{}{})
+}
+
 func (yga *YgaAnalytics) user(DeviceId, UserId, AppName, Platform, Time string, Sever int, DataType string, properties map[string]interface{}) error {
 	if properties == nil {
 		return errors.New("invalid params for " + DataType + ": properties is nil")
@@ -142,7 +203,7 @@ func (yga *YgaAnalytics) add(DeviceId, UserId, AppName, Platform, Time, EventNam
 	propertiesjson, _ := json.Marshal(properties)
 	Data := Data{
 		DeviceId:   DeviceId,
-		UserId:     DeviceId,
+		UserId:     UserId,
 		AppName:    AppName,
 		Platform:   Platform,
 		Time:       Time,
@@ -155,8 +216,15 @@ func (yga *YgaAnalytics) add(DeviceId, UserId, AppName, Platform, Time, EventNam
 	// 检查数据格式, 并将时间类型数据转为符合格式要求的字符串
 	err := formatProperties(&Data, properties)
 	if err != nil {
+		yga.logger.Error("事件格式校验失败", F("event_name", EventName), F("error", err.Error()))
+		yga.metrics.IncEventsDropped("invalid_format")
 		return err
 	}
 
-	return yga.consumer.Add(Data)
+	if err := yga.consumer.Add(Data); err != nil {
+		yga.metrics.IncEventsDropped("consumer_error")
+		return err
+	}
+	yga.metrics.IncEventsEnqueued()
+	return nil
 }
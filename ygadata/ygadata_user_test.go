@@ -0,0 +1,59 @@
+package ygadata
+
+import "testing"
+
+func TestUserOperations(t *testing.T) {
+	c := &captureConsumer{}
+	yga := New(c)
+
+	if err := yga.UserSetOnce("dev1", "", "app", "ios", "", 0, map[string]interface{}{"k": "v"}); err != nil {
+		t.Fatalf("UserSetOnce() error = %v", err)
+	}
+	if err := yga.UserAdd("dev1", "", "app", "ios", "", 0, map[string]interface{}{"count": 1}); err != nil {
+		t.Fatalf("UserAdd() error = %v", err)
+	}
+	if err := yga.UserAppend("dev1", "", "app", "ios", "", 0, map[string]interface{}{"tags": []string{"a"}}); err != nil {
+		t.Fatalf("UserAppend() error = %v", err)
+	}
+	if err := yga.UserUnset("dev1", "", "app", "ios", "", 0, []string{"k"}); err != nil {
+		t.Fatalf("UserUnset() error = %v", err)
+	}
+	if err := yga.UserDelete("dev1", "", "app", "ios", "", 0); err != nil {
+		t.Fatalf("UserDelete() error = %v", err)
+	}
+
+	wantTypes := []string{UserSetOnce, UserAdd, UserAppend, UserUnset, UserDel}
+	if len(c.added) != len(wantTypes) {
+		t.Fatalf("got %d events, want %d", len(c.added), len(wantTypes))
+	}
+	for i, want := range wantTypes {
+		if c.added[i].Type != want {
+			t.Errorf("event %d Type = %q, want %q", i, c.added[i].Type, want)
+		}
+		if c.added[i].EventName != User {
+			t.Errorf("event %d EventName = %q, want %q", i, c.added[i].EventName, User)
+		}
+	}
+}
+
+func TestUserAddRejectsNonNumericProperty(t *testing.T) {
+	c := &captureConsumer{}
+	yga := New(c)
+
+	err := yga.UserAdd("dev1", "", "app", "ios", "", 0, map[string]interface{}{"count": "not-a-number"})
+	if err == nil {
+		t.Fatal("UserAdd() error = nil, want error for non-numeric property")
+	}
+	if len(c.added) != 0 {
+		t.Fatalf("got %d events, want 0 since UserAdd should have rejected before reaching the consumer", len(c.added))
+	}
+}
+
+func TestUserUnsetRequiresKeys(t *testing.T) {
+	c := &captureConsumer{}
+	yga := New(c)
+
+	if err := yga.UserUnset("dev1", "", "app", "ios", "", 0, nil); err == nil {
+		t.Fatal("UserUnset() error = nil, want error for empty keys")
+	}
+}